@@ -0,0 +1,334 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// AnnotationMode controls how BuildkiteAnnotationSink turns entries into
+// `buildkite-agent annotate` calls.
+type AnnotationMode int
+
+const (
+	// AnnotationPerMessage creates one annotation per entry, the tool's
+	// original behavior.
+	AnnotationPerMessage AnnotationMode = iota
+	// AnnotationAggregated maintains a single annotation that's re-rendered
+	// with the full transcript so far, overwriting in place.
+	AnnotationAggregated
+	// AnnotationSummary is like AnnotationAggregated but renders a compact
+	// running summary with the full transcript tucked behind a disclosure.
+	AnnotationSummary
+)
+
+// annotationFlushInterval bounds how often aggregated/summary annotations
+// are re-rendered, so a long session doesn't hammer buildkite-agent with a
+// fresh `annotate` call on every line.
+const annotationFlushInterval = 3 * time.Second
+
+// annotationContext is the fixed context used for aggregated and summary
+// annotations so each re-render overwrites the previous one instead of
+// creating a new annotation.
+const annotationContext = "claude-transcript"
+
+func parseAnnotationMode(s string) (AnnotationMode, error) {
+	switch strings.ToLower(s) {
+	case "", "per-message":
+		return AnnotationPerMessage, nil
+	case "aggregated":
+		return AnnotationAggregated, nil
+	case "summary":
+		return AnnotationSummary, nil
+	default:
+		return AnnotationPerMessage, fmt.Errorf("invalid --annotation-mode value %q (want per-message, aggregated, or summary)", s)
+	}
+}
+
+// TranscriptState accumulates running stats about the session as entries
+// are parsed, so sinks that want a running summary (aggregated/summary
+// annotations, the end-of-run usage report) don't each have to re-derive
+// them from scratch.
+type TranscriptState struct {
+	SessionID     string
+	Model         string
+	Entries       []ChatEntry
+	ToolCallCount int
+	ErrorCount    int
+	InputTokens   int
+	OutputTokens  int
+	ByModel       map[string]*ModelUsage
+	// ToolUseNames maps a tool_use content item's ID to its tool name, so
+	// that when the matching tool_result arrives (in a later message) it can
+	// be rendered by the same ToolRenderer.
+	ToolUseNames map[string]string
+}
+
+// Update folds a freshly parsed message into the running state. It's called
+// from parseLine so every sink sees the same counters regardless of which
+// sinks are active.
+func (t *TranscriptState) Update(msg Message) {
+	if msg.SessionID != "" {
+		t.SessionID = msg.SessionID
+	}
+
+	model := msg.Message.Model
+	if model == "" {
+		model = msg.Model
+	}
+	if model != "" {
+		t.Model = model
+	}
+
+	if msg.Message.Usage.InputTokens > 0 || msg.Message.Usage.OutputTokens > 0 {
+		t.InputTokens += msg.Message.Usage.InputTokens
+		t.OutputTokens += msg.Message.Usage.OutputTokens
+
+		if t.ByModel == nil {
+			t.ByModel = make(map[string]*ModelUsage)
+		}
+		key := model
+		if key == "" {
+			key = "unknown"
+		}
+		usage, ok := t.ByModel[key]
+		if !ok {
+			usage = &ModelUsage{}
+			t.ByModel[key] = usage
+		}
+		usage.InputTokens += msg.Message.Usage.InputTokens
+		usage.OutputTokens += msg.Message.Usage.OutputTokens
+	}
+
+	for _, contentItem := range msg.Message.Content {
+		switch contentItem.Type {
+		case "tool_use":
+			t.ToolCallCount++
+			if contentItem.ID != "" {
+				if t.ToolUseNames == nil {
+					t.ToolUseNames = make(map[string]string)
+				}
+				t.ToolUseNames[contentItem.ID] = contentItem.Name
+			}
+		case "tool_result":
+			if contentItem.IsError {
+				t.ErrorCount++
+			}
+		}
+	}
+}
+
+// BuildkiteAnnotationSink creates Buildkite annotations from entries. In
+// per-message mode it creates one annotation per entry, mirroring the
+// tool's original behavior. In aggregated/summary mode it maintains a
+// single annotation, re-rendered (and rate-limited) as new entries arrive.
+type BuildkiteAnnotationSink struct {
+	mode      AnnotationMode
+	state     *TranscriptState
+	lastFlush time.Time
+}
+
+func NewBuildkiteAnnotationSink(mode AnnotationMode, state *TranscriptState) *BuildkiteAnnotationSink {
+	return &BuildkiteAnnotationSink{mode: mode, state: state}
+}
+
+func (s *BuildkiteAnnotationSink) Write(entry ChatEntry, raw string) error {
+	switch s.mode {
+	case AnnotationAggregated, AnnotationSummary:
+		if time.Since(s.lastFlush) < annotationFlushInterval {
+			return nil
+		}
+		return s.flush()
+	default:
+		createBuildkiteAnnotation(raw, entry.LineNumber, entry.Timestamp, s.state)
+		return nil
+	}
+}
+
+func (s *BuildkiteAnnotationSink) Close() error {
+	switch s.mode {
+	case AnnotationAggregated, AnnotationSummary:
+		// Always flush on close so the final state is reflected, even if
+		// it lands inside the debounce window.
+		return s.flush()
+	default:
+		return nil
+	}
+}
+
+func (s *BuildkiteAnnotationSink) flush() error {
+	s.lastFlush = time.Now()
+
+	var markdown string
+	style := "info"
+	if s.state.ErrorCount > 0 {
+		style = "error"
+	}
+
+	switch s.mode {
+	case AnnotationSummary:
+		markdown = renderSummaryMarkdown(s.state)
+	default:
+		markdown = renderTranscriptMarkdown(s.state.Entries)
+	}
+
+	return annotateBuildkite(markdown, style, annotationContext)
+}
+
+// renderTranscriptMarkdown renders the full transcript so far as one
+// markdown document with the per-entry markup inlined as HTML, reusing the
+// same html/template (and view model) that HTMLTranscriptSink renders its
+// standalone document from — Buildkite annotations accept inline HTML
+// inside their markdown body, so there's no separate markdown-only
+// per-entry renderer to keep in sync with it.
+func renderTranscriptMarkdown(entries []ChatEntry) string {
+	entriesHTML, err := renderHTMLEntries(entries)
+	if err != nil {
+		log.Printf("Warning: rendering transcript HTML failed: %v", err)
+		entriesHTML = fmt.Sprintf("_error rendering transcript: %v_", err)
+	}
+	return fmt.Sprintf("### Claude Code Transcript (%d messages)\n\n%s", len(entries), entriesHTML)
+}
+
+// renderSummaryMarkdown renders a compact header with running counters plus
+// the full transcript tucked behind a disclosure.
+func renderSummaryMarkdown(state *TranscriptState) string {
+	var body strings.Builder
+
+	status := "✅ passing"
+	if state.ErrorCount > 0 {
+		status = fmt.Sprintf("❌ %d error(s)", state.ErrorCount)
+	}
+
+	body.WriteString("### Claude Code Session Summary\n\n")
+	body.WriteString("| | |\n|---|---|\n")
+	body.WriteString(fmt.Sprintf("| Session | `%s` |\n", state.SessionID))
+	body.WriteString(fmt.Sprintf("| Model | `%s` |\n", state.Model))
+	body.WriteString(fmt.Sprintf("| Messages | %d |\n", len(state.Entries)))
+	body.WriteString(fmt.Sprintf("| Tool calls | %d |\n", state.ToolCallCount))
+	body.WriteString(fmt.Sprintf("| Tokens | %d in / %d out |\n", state.InputTokens, state.OutputTokens))
+	body.WriteString(fmt.Sprintf("| Status | %s |\n", status))
+
+	body.WriteString("\n<details>\n<summary>Show full transcript</summary>\n\n")
+	body.WriteString(renderTranscriptMarkdown(state.Entries))
+	body.WriteString("\n</details>")
+
+	return body.String()
+}
+
+// annotateBuildkite pipes markdown to `buildkite-agent annotate` under the
+// given context, which overwrites any existing annotation with that
+// context rather than creating a new one.
+func annotateBuildkite(markdown, style, context string) error {
+	cmd := exec.Command("buildkite-agent", "annotate",
+		"--style", style,
+		"--context", context,
+		"--priority", "5")
+	cmd.Stdin = strings.NewReader(markdown)
+
+	if err := cmd.Run(); err != nil {
+		log.Printf("Warning: Failed to create Buildkite annotation: %v", err)
+		return nil
+	}
+	return nil
+}
+
+// createBuildkiteAnnotation creates a Buildkite annotation by parsing the raw JSON line
+func createBuildkiteAnnotation(rawJSONLine string, lineNumber int, timestamp string, state *TranscriptState) {
+	// Skip empty lines
+	rawJSONLine = strings.TrimSpace(rawJSONLine)
+	if rawJSONLine == "" {
+		return
+	}
+
+	// Create markdown content for the annotation
+	var markdownContent strings.Builder
+	var speaker string
+	var content string
+	var hasError bool
+	var style string = "info" // default style
+
+	// Add timestamp and line number
+	markdownContent.WriteString(fmt.Sprintf("**Message %d** - `%s`\n\n", lineNumber, timestamp))
+
+	// Try to parse as JSON to extract clean content
+	if strings.HasPrefix(rawJSONLine, "{") {
+		var msg Message
+		if err := json.Unmarshal([]byte(rawJSONLine), &msg); err == nil {
+			// Extract clean content from JSON and check for errors
+			speaker, content, hasError, _ = formatJSONMessage(msg, state)
+
+			// Skip annotation for unknown message types
+			if content == "Unknown message type" {
+				return
+			}
+		} else {
+			// Not valid JSON, treat as plain text
+			speaker = "SYSTEM"
+			content = rawJSONLine
+		}
+	} else {
+		// Plain text line
+		speaker = "SYSTEM"
+		content = rawJSONLine
+	}
+
+	// Add speaker with appropriate styling
+	switch speaker {
+	case "ASSISTANT":
+		markdownContent.WriteString("🤖 **ASSISTANT**:\n\n")
+		style = "info"
+	case "USER":
+		markdownContent.WriteString("👤 **USER**:\n\n")
+		if hasError {
+			style = "error"
+		} else {
+			style = "success"
+		}
+	case "SYSTEM":
+		markdownContent.WriteString("⚙️ **SYSTEM**:\n\n")
+		style = "warning"
+	default:
+		markdownContent.WriteString(fmt.Sprintf("**%s**:\n\n", speaker))
+		style = "info"
+	}
+
+	// Add clean content. formatJSONMessage never embeds ANSI codes, but
+	// plain-text passthrough lines might, so strip defensively — annotations
+	// are rendered as markdown and must never contain raw escapes.
+	if content != "" {
+		markdownContent.WriteString(stripANSI(content))
+	}
+
+	// Add raw JSON disclosure at the end with pretty formatting
+	markdownContent.WriteString("\n\n<details>\n<summary>Show JSON</summary>\n\n```json\n")
+
+	// Pretty-format the JSON if possible
+	if strings.HasPrefix(rawJSONLine, "{") {
+		var jsonObj interface{}
+		if err := json.Unmarshal([]byte(rawJSONLine), &jsonObj); err == nil {
+			if prettyJSON, err := json.MarshalIndent(jsonObj, "", "  "); err == nil {
+				markdownContent.WriteString(string(prettyJSON))
+			} else {
+				// Fallback to raw JSON if formatting fails
+				markdownContent.WriteString(rawJSONLine)
+			}
+		} else {
+			// Fallback to raw JSON if parsing fails
+			markdownContent.WriteString(rawJSONLine)
+		}
+	} else {
+		// Not JSON, just show as-is
+		markdownContent.WriteString(rawJSONLine)
+	}
+
+	markdownContent.WriteString("\n```\n\n</details>")
+
+	// Create context to ensure unique annotations
+	context := fmt.Sprintf("chat-message-%d", lineNumber)
+
+	_ = annotateBuildkite(markdownContent.String(), style, context)
+}
@@ -0,0 +1,26 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestRenderTranscriptMarkdownPreservesDisclosure guards against the
+// aggregated/summary annotation path regressing to literal escaped
+// <details>/<summary> tags: renderTranscriptMarkdown renders through the
+// same shared template as HTMLTranscriptSink, so a long tool call's
+// disclosure block must come through as a real element, not escaped text.
+func TestRenderTranscriptMarkdownPreservesDisclosure(t *testing.T) {
+	longCmd := strings.Repeat("echo line\n", 50)
+	used := bashToolRenderer{}.RenderUse(map[string]interface{}{"command": longCmd})
+	entries := []ChatEntry{{LineNumber: 1, Speaker: "ASSISTANT", Content: used, Timestamp: "00:01"}}
+
+	md := renderTranscriptMarkdown(entries)
+
+	if strings.Contains(md, "&lt;details&gt;") {
+		t.Fatalf("aggregated annotation markdown escaped the disclosure tag:\n%s", md)
+	}
+	if !strings.Contains(md, "<details><summary>Show more...</summary>") {
+		t.Fatalf("expected a real details/summary element in the aggregated annotation, got:\n%s", md)
+	}
+}
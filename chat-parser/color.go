@@ -0,0 +1,96 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/mattn/go-colorable"
+	"github.com/mattn/go-isatty"
+)
+
+// ColorMode controls when terminal styling is applied to stdout output.
+type ColorMode int
+
+const (
+	ColorAuto ColorMode = iota
+	ColorAlways
+	ColorNever
+)
+
+// parseColorMode parses the --color flag value. An empty string means the
+// flag wasn't given and defaults to auto.
+func parseColorMode(s string) (ColorMode, error) {
+	switch strings.ToLower(s) {
+	case "":
+		return ColorAuto, nil
+	case "auto":
+		return ColorAuto, nil
+	case "always":
+		return ColorAlways, nil
+	case "never":
+		return ColorNever, nil
+	default:
+		return ColorAuto, fmt.Errorf("invalid --color value %q (want auto, always, or never)", s)
+	}
+}
+
+// colorEnabled resolves mode against NO_COLOR and whether stdout is a
+// terminal, following the convention most CLIs use.
+func colorEnabled(mode ColorMode) bool {
+	if os.Getenv("NO_COLOR") != "" {
+		return false
+	}
+	switch mode {
+	case ColorAlways:
+		return true
+	case ColorNever:
+		return false
+	default:
+		return isatty.IsTerminal(os.Stdout.Fd()) || isatty.IsCygwinTerminal(os.Stdout.Fd())
+	}
+}
+
+// speakerColors and contentColors style the speaker label and the message
+// body respectively. Lookups fall back to plain white for unknown speakers.
+var (
+	speakerColors = map[string]*color.Color{
+		"ASSISTANT": color.New(color.FgGreen, color.Bold),
+		"USER":      color.New(color.FgBlue, color.Bold),
+		"SYSTEM":    color.New(color.FgYellow, color.Bold),
+	}
+	contentColors = map[string]*color.Color{
+		"ASSISTANT": color.New(color.FgGreen),
+		"USER":      color.New(color.FgBlue),
+		"SYSTEM":    color.New(color.FgHiBlack),
+	}
+	defaultSpeakerColor = color.New(color.FgWhite)
+	defaultContentColor = color.New(color.FgWhite)
+	lineNumberColor     = color.New(color.FgHiBlack)
+	timestampColor      = color.New(color.Faint)
+	headerColor         = color.New(color.FgCyan, color.Bold)
+)
+
+// printHeader prints the transcript banner, styled if color is enabled.
+func printHeader(mode ColorMode) {
+	out := colorable.NewColorableStdout()
+	if colorEnabled(mode) {
+		headerColor.Fprintln(out, "=== Claude Code Chat Transcript ===")
+	} else {
+		fmt.Fprintln(out, "=== Claude Code Chat Transcript ===")
+	}
+	fmt.Fprintln(out)
+}
+
+// ansiEscapePattern matches ANSI SGR escape sequences.
+var ansiEscapePattern = regexp.MustCompile("\x1b\\[[0-9;]*m")
+
+// stripANSI removes terminal color escape sequences from s. Sinks that
+// render to Buildkite annotations, HTML, or other non-terminal surfaces use
+// this defensively, since formatJSONMessage no longer embeds ANSI codes in
+// ChatEntry.Content but RawLine or older content may still carry them.
+func stripANSI(s string) string {
+	return ansiEscapePattern.ReplaceAllString(s, "")
+}
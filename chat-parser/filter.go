@@ -0,0 +1,185 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Filter decides whether an entry should reach the sinks. parseAndStreamOutput
+// runs every entry through the configured FilterChain right after parseLine
+// and before any sink sees it, mirroring the noisy-message skip/keep rules
+// humanlog offers.
+type Filter interface {
+	Allow(entry *ChatEntry) bool
+}
+
+// FilterChain allows an entry only if every filter in it does.
+type FilterChain []Filter
+
+func (c FilterChain) Allow(entry *ChatEntry) bool {
+	for _, f := range c {
+		if !f.Allow(entry) {
+			return false
+		}
+	}
+	return true
+}
+
+// toolFilter skips or keeps entries based on which tools they mention,
+// derived from ChatEntry.Tools (the tool_use/tool_result names formatJSONMessage
+// collected while rendering the entry).
+type toolFilter struct {
+	names map[string]bool
+	keep  bool // true: only allow entries mentioning one of names. false: skip them.
+}
+
+func (f toolFilter) Allow(entry *ChatEntry) bool {
+	mentioned := false
+	for _, name := range entry.Tools {
+		if f.names[name] {
+			mentioned = true
+			break
+		}
+	}
+	if f.keep {
+		return mentioned
+	}
+	return !mentioned
+}
+
+// typeFilter skips entries whose speaker (assistant/user/system) is in names.
+type typeFilter struct {
+	names map[string]bool
+}
+
+func (f typeFilter) Allow(entry *ChatEntry) bool {
+	return !f.names[strings.ToLower(entry.Speaker)]
+}
+
+// level is a coarse severity used by --min-level, since the tool has no
+// structured log levels of its own: an entry is "error" if its tool result
+// (or message) reported an error, "info" otherwise.
+type level int
+
+const (
+	levelInfo level = iota
+	levelWarn
+	levelError
+)
+
+func parseLevel(s string) (level, error) {
+	switch strings.ToLower(s) {
+	case "", "info":
+		return levelInfo, nil
+	case "warn", "warning":
+		return levelWarn, nil
+	case "error":
+		return levelError, nil
+	default:
+		return levelInfo, fmt.Errorf("invalid level %q (want info, warn, or error)", s)
+	}
+}
+
+func entryLevel(entry *ChatEntry) level {
+	if entry.HasError {
+		return levelError
+	}
+	if entry.Speaker == "SYSTEM" {
+		return levelWarn
+	}
+	return levelInfo
+}
+
+// levelFilter allows only entries at or above a minimum severity.
+type levelFilter struct {
+	min level
+}
+
+func (f levelFilter) Allow(entry *ChatEntry) bool {
+	return entryLevel(entry) >= f.min
+}
+
+// grepFilter allows only entries whose content matches a regexp.
+type grepFilter struct {
+	pattern *regexp.Regexp
+}
+
+func (f grepFilter) Allow(entry *ChatEntry) bool {
+	return f.pattern.MatchString(entry.Content)
+}
+
+// FilteredSink wraps a Sink so only entries filterChain allows reach it.
+// buildSinks wraps presentation sinks (stdio, Buildkite, html, webhook) with
+// this but deliberately leaves archival sinks (RawFileSink, JSONLSink)
+// unwrapped, so --skip-tool/--skip-type/--min-level/--grep thin out what's
+// rendered without silently dropping entries from the raw/JSONL record —
+// e.g. hiding TodoWrite calls from Buildkite annotations while keeping them
+// in the -o file.
+type FilteredSink struct {
+	sink  Sink
+	chain FilterChain
+}
+
+func NewFilteredSink(sink Sink, chain FilterChain) *FilteredSink {
+	return &FilteredSink{sink: sink, chain: chain}
+}
+
+func (f *FilteredSink) Write(entry ChatEntry, raw string) error {
+	if !f.chain.Allow(&entry) {
+		return nil
+	}
+	return f.sink.Write(entry, raw)
+}
+
+func (f *FilteredSink) Close() error {
+	return f.sink.Close()
+}
+
+// splitNames turns a comma-separated flag value into a lookup set.
+func splitNames(s string) map[string]bool {
+	names := make(map[string]bool)
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part != "" {
+			names[part] = true
+		}
+	}
+	return names
+}
+
+// buildFilterChain validates the skip/keep flags (mutually exclusive per
+// dimension) and assembles the FilterChain main() runs every entry through.
+func buildFilterChain(skipTool, keepTool, skipType, minLevelSpec, grepPattern string) (FilterChain, error) {
+	if skipTool != "" && keepTool != "" {
+		return nil, fmt.Errorf("--skip-tool and --keep-tool are mutually exclusive")
+	}
+
+	var chain FilterChain
+
+	if skipTool != "" {
+		chain = append(chain, toolFilter{names: splitNames(skipTool), keep: false})
+	}
+	if keepTool != "" {
+		chain = append(chain, toolFilter{names: splitNames(keepTool), keep: true})
+	}
+	if skipType != "" {
+		chain = append(chain, typeFilter{names: splitNames(strings.ToLower(skipType))})
+	}
+	if minLevelSpec != "" {
+		min, err := parseLevel(minLevelSpec)
+		if err != nil {
+			return nil, err
+		}
+		chain = append(chain, levelFilter{min: min})
+	}
+	if grepPattern != "" {
+		re, err := regexp.Compile(grepPattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid --grep pattern: %w", err)
+		}
+		chain = append(chain, grepFilter{pattern: re})
+	}
+
+	return chain, nil
+}
@@ -0,0 +1,48 @@
+package main
+
+import "testing"
+
+type fakeSink struct {
+	writes int
+}
+
+func (f *fakeSink) Write(entry ChatEntry, raw string) error { f.writes++; return nil }
+func (f *fakeSink) Close() error                            { return nil }
+
+func TestFilteredSinkSkipsDisallowedEntries(t *testing.T) {
+	chain := FilterChain{toolFilter{names: map[string]bool{"TodoWrite": true}, keep: false}}
+	fake := &fakeSink{}
+	fs := NewFilteredSink(fake, chain)
+
+	if err := fs.Write(ChatEntry{Tools: []string{"Bash"}}, "raw"); err != nil {
+		t.Fatal(err)
+	}
+	if err := fs.Write(ChatEntry{Tools: []string{"TodoWrite"}}, "raw"); err != nil {
+		t.Fatal(err)
+	}
+
+	if fake.writes != 1 {
+		t.Fatalf("expected 1 write to reach the wrapped sink, got %d", fake.writes)
+	}
+}
+
+func TestFilteredSinkPassesEverythingWithEmptyChain(t *testing.T) {
+	fake := &fakeSink{}
+	fs := NewFilteredSink(fake, nil)
+
+	for i := 0; i < 3; i++ {
+		if err := fs.Write(ChatEntry{}, "raw"); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if fake.writes != 3 {
+		t.Fatalf("expected every write to reach the wrapped sink, got %d", fake.writes)
+	}
+}
+
+func TestBuildFilterChainRejectsConflictingToolFlags(t *testing.T) {
+	if _, err := buildFilterChain("Bash", "Read", "", "", ""); err == nil {
+		t.Fatal("expected an error when --skip-tool and --keep-tool are both set")
+	}
+}
@@ -0,0 +1,249 @@
+package main
+
+import (
+	"html/template"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// HTMLTranscriptSink accumulates entries and writes a single self-contained
+// HTML document on Close: inlined CSS and JS, speaker avatars, relative
+// timestamps, and collapsible <details> blocks for long tool calls.
+type HTMLTranscriptSink struct {
+	path    string
+	entries []ChatEntry
+}
+
+func NewHTMLTranscriptSink(path string) *HTMLTranscriptSink {
+	return &HTMLTranscriptSink{path: path}
+}
+
+func (s *HTMLTranscriptSink) Write(entry ChatEntry, raw string) error {
+	if entry.Content == "" {
+		return nil
+	}
+	s.entries = append(s.entries, entry)
+	return nil
+}
+
+func (s *HTMLTranscriptSink) Close() error {
+	rendered, err := renderHTMLTranscript(s.entries)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(s.path, []byte(rendered), 0o644)
+}
+
+// htmlTranscriptEntry is the view model htmlTranscriptTemplate renders, one
+// per ChatEntry: Content is pre-split into fenced-code, disclosure, and
+// plain segments so the template doesn't need its own markdown parser.
+type htmlTranscriptEntry struct {
+	LineNumber int
+	Speaker    string
+	Class      string
+	Avatar     string
+	Timestamp  string
+	HasError   bool
+	Collapsed  bool
+	Segments   []htmlSegment
+}
+
+// htmlSegment is a fenced code block, a <details>/<summary> disclosure
+// (nesting its own segments), or a plain-text run extracted from an entry's
+// content.
+type htmlSegment struct {
+	IsCode       bool
+	Lang         string
+	Text         string
+	IsDisclosure bool
+	Summary      string
+	Segments     []htmlSegment
+}
+
+var avatars = map[string]string{
+	"ASSISTANT": "🤖",
+	"USER":      "👤",
+	"SYSTEM":    "⚙️",
+}
+
+// fencePattern splits a run of text into fenced code blocks and the plain
+// text between them.
+var fencePattern = regexp.MustCompile("(?s)```([a-zA-Z0-9_+-]*)\\n(.*?)```")
+
+// disclosurePattern matches the literal <details><summary>...</summary>
+// ...</details> wrapper withDisclosure (tools.go) emits for long tool
+// output. It's matched before fencePattern so the fenced code blocks it
+// wraps are recognized inside the disclosure body rather than as part of
+// the surrounding plain text.
+var disclosurePattern = regexp.MustCompile(`(?s)<details>\n<summary>(.*?)</summary>\n\n(.*?)\n\n</details>`)
+
+// splitSegments splits content into disclosure, fenced-code, and plain-text
+// segments for the html/template to render. Plain text is rendered through
+// {{.Text}}, which html/template auto-escapes; the <details>/<summary>
+// wrapper itself is never escaped since it's reconstructed by the template
+// rather than passed through as a text segment.
+func splitSegments(content string) []htmlSegment {
+	var segments []htmlSegment
+	last := 0
+	for _, m := range disclosurePattern.FindAllStringSubmatchIndex(content, -1) {
+		if m[0] > last {
+			segments = append(segments, splitCodeSegments(content[last:m[0]])...)
+		}
+		summary := content[m[2]:m[3]]
+		body := content[m[4]:m[5]]
+		segments = append(segments, htmlSegment{
+			IsDisclosure: true,
+			Summary:      summary,
+			Segments:     splitSegments(body),
+		})
+		last = m[1]
+	}
+	if last < len(content) {
+		segments = append(segments, splitCodeSegments(content[last:])...)
+	}
+	return segments
+}
+
+// splitCodeSegments splits a disclosure-free run of text into fenced-code
+// and plain-text segments.
+func splitCodeSegments(content string) []htmlSegment {
+	var segments []htmlSegment
+	last := 0
+	for _, m := range fencePattern.FindAllStringSubmatchIndex(content, -1) {
+		if m[0] > last {
+			segments = append(segments, htmlSegment{Text: content[last:m[0]]})
+		}
+		lang := content[m[2]:m[3]]
+		code := content[m[4]:m[5]]
+		segments = append(segments, htmlSegment{IsCode: true, Lang: lang, Text: code})
+		last = m[1]
+	}
+	if last < len(content) {
+		segments = append(segments, htmlSegment{Text: content[last:]})
+	}
+	return segments
+}
+
+// transcriptView builds the view model htmlTemplates renders, one entry per
+// ChatEntry. It's shared by every consumer that turns entries into markup:
+// HTMLTranscriptSink's standalone document and BuildkiteAnnotationSink's
+// aggregated/summary annotations (which embed the "entries" fragment inline
+// in their markdown body) both render from the same view model and the same
+// template, so there's exactly one place that knows how to turn a ChatEntry
+// into HTML.
+func transcriptView(entries []ChatEntry) []htmlTranscriptEntry {
+	view := make([]htmlTranscriptEntry, 0, len(entries))
+	for _, entry := range entries {
+		content := stripANSI(entry.Content)
+		view = append(view, htmlTranscriptEntry{
+			LineNumber: entry.LineNumber,
+			Speaker:    entry.Speaker,
+			Class:      strings.ToLower(entry.Speaker),
+			Avatar:     avatars[entry.Speaker],
+			Timestamp:  entry.Timestamp,
+			HasError:   entry.HasError,
+			Collapsed:  len(content) > 800,
+			Segments:   splitSegments(content),
+		})
+	}
+	return view
+}
+
+// renderHTMLTranscript renders entries as a self-contained HTML document
+// (inlined CSS/JS), for HTMLTranscriptSink.
+func renderHTMLTranscript(entries []ChatEntry) (string, error) {
+	var out strings.Builder
+	if err := htmlTemplates.ExecuteTemplate(&out, "document", transcriptView(entries)); err != nil {
+		return "", err
+	}
+	return out.String(), nil
+}
+
+// renderHTMLEntries renders just the repeating per-entry markup (no
+// surrounding <html>/<head>/<script>), for embedding inline inside a
+// Buildkite annotation's markdown body.
+func renderHTMLEntries(entries []ChatEntry) (string, error) {
+	var out strings.Builder
+	if err := htmlTemplates.ExecuteTemplate(&out, "entries", transcriptView(entries)); err != nil {
+		return "", err
+	}
+	return out.String(), nil
+}
+
+// htmlTemplates defines the "entries" fragment once and reuses it from the
+// "document" template, so the standalone HTML transcript and the inline
+// markup embedded in aggregated Buildkite annotations never drift apart.
+var htmlTemplates = template.Must(template.New("document").Parse(`
+{{define "segment"}}
+  {{if .IsCode}}<pre><code class="hl language-{{.Lang}}">{{.Text}}</code></pre>
+  {{else if .IsDisclosure}}<details><summary>{{.Summary}}</summary>{{range .Segments}}{{template "segment" .}}{{end}}</details>
+  {{else}}<div>{{.Text}}</div>{{end}}
+{{end}}
+
+{{define "entries"}}
+{{range .}}
+<div class="entry {{.Class}}{{if .HasError}} error{{end}}">
+  <div class="avatar">{{.Avatar}}</div>
+  <div class="body">
+    <div class="meta">[{{printf "%03d" .LineNumber}}] [{{.Timestamp}}] {{.Speaker}}</div>
+    {{if .Collapsed}}<details><summary>Show content</summary>{{end}}
+    {{range .Segments}}{{template "segment" .}}{{end}}
+    {{if .Collapsed}}</details>{{end}}
+  </div>
+</div>
+{{end}}
+{{end}}
+
+{{define "document"}}
+<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Claude Code Chat Transcript</title>
+<style>
+body{font-family:-apple-system,sans-serif;background:#1e1e1e;color:#ddd;margin:2rem}
+h1{font-size:1.2rem}
+.entry{display:flex;gap:0.75rem;border-left:3px solid #444;padding:0.5rem 1rem;margin-bottom:0.75rem}
+.entry.assistant{border-color:#3fb950}
+.entry.user{border-color:#58a6ff}
+.entry.system{border-color:#d29922}
+.entry.error{border-color:#f85149}
+.avatar{font-size:1.4rem;line-height:1}
+.meta{color:#888;font-size:0.8rem;margin-bottom:0.25rem}
+.body{flex:1;min-width:0}
+pre{white-space:pre-wrap;word-break:break-word;background:#161616;padding:0.5rem;border-radius:4px;overflow-x:auto}
+summary{cursor:pointer;color:#9aa}
+.hl-kw{color:#c586c0}.hl-str{color:#ce9178}.hl-num{color:#b5cea8}.hl-com{color:#6a9955}
+</style>
+</head>
+<body>
+<h1>Claude Code Chat Transcript</h1>
+{{template "entries" .}}
+<script>
+// Minimal client-side highlighter: no bundled highlight.js, just enough to
+// color keywords/strings/numbers/comments for the languages this tool emits
+// (bash, json, go, diff). Runs once on load over every .hl code block.
+(function () {
+  var rules = {
+    bash: [[/#.*$/gm, "hl-com"], [/"(?:[^"\\]|\\.)*"/g, "hl-str"], [/\b(if|then|fi|for|do|done|echo|cd)\b/g, "hl-kw"]],
+    json: [[/"(?:[^"\\]|\\.)*"(?=\s*:)/g, "hl-kw"], [/"(?:[^"\\]|\\.)*"/g, "hl-str"], [/\b-?\d+(\.\d+)?\b/g, "hl-num"]],
+    go: [[/\/\/.*$/gm, "hl-com"], [/"(?:[^"\\]|\\.)*"/g, "hl-str"], [/\b(func|package|import|return|if|else|range|for|var|const)\b/g, "hl-kw"]],
+    diff: [[/^-.*$/gm, "hl-str"], [/^\+.*$/gm, "hl-num"]]
+  };
+  document.querySelectorAll("code.hl").forEach(function (el) {
+    var lang = (el.className.match(/language-(\w+)/) || [])[1];
+    var set = rules[lang];
+    if (!set) return;
+    var html = el.innerHTML;
+    set.forEach(function (rule) {
+      html = html.replace(rule[0], function (m) { return '<span class="' + rule[1] + '">' + m + "</span>"; });
+    });
+    el.innerHTML = html;
+  });
+})();
+</script>
+</body>
+</html>
+{{end}}
+`))
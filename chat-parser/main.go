@@ -6,26 +6,10 @@ import (
 	"fmt"
 	"log"
 	"os"
-	"os/exec"
 	"strings"
 	"time"
 )
 
-// ANSI color codes
-const (
-	ColorReset   = "\033[0m"
-	ColorBold    = "\033[1m"
-	ColorDim     = "\033[2m"
-	ColorRed     = "\033[31m"
-	ColorGreen   = "\033[32m"
-	ColorYellow  = "\033[33m"
-	ColorBlue    = "\033[34m"
-	ColorMagenta = "\033[35m"
-	ColorCyan    = "\033[36m"
-	ColorWhite   = "\033[37m"
-	ColorGray    = "\033[90m"
-)
-
 var staticLineNum int
 var startTime time.Time
 
@@ -42,121 +26,21 @@ func formatRelativeTime(elapsed time.Duration) string {
 	return fmt.Sprintf("%02d:%02d", minutes, seconds)
 }
 
-// createBuildkiteAnnotation creates a Buildkite annotation by parsing the raw JSON line
-func createBuildkiteAnnotation(rawJSONLine string, lineNumber int, timestamp string) {
-	// Skip empty lines
-	rawJSONLine = strings.TrimSpace(rawJSONLine)
-	if rawJSONLine == "" {
-		return
-	}
-
-	// Create markdown content for the annotation
-	var markdownContent strings.Builder
-	var speaker string
-	var content string
-	var hasError bool
-	var style string = "info" // default style
-
-	// Add timestamp and line number
-	markdownContent.WriteString(fmt.Sprintf("**Message %d** - `%s`\n\n", lineNumber, timestamp))
-
-	// Try to parse as JSON to extract clean content
-	if strings.HasPrefix(rawJSONLine, "{") {
-		var msg Message
-		if err := json.Unmarshal([]byte(rawJSONLine), &msg); err == nil {
-			// Extract clean content from JSON without ANSI codes and check for errors
-			speaker, content, hasError = extractCleanJSONContentWithErrorCheck(msg)
-
-			// Skip annotation for unknown message types
-			if content == "Unknown message type" {
-				return
-			}
-		} else {
-			// Not valid JSON, treat as plain text
-			speaker = "SYSTEM"
-			content = rawJSONLine
-		}
-	} else {
-		// Plain text line
-		speaker = "SYSTEM"
-		content = rawJSONLine
-	}
-
-	// Add speaker with appropriate styling
-	switch speaker {
-	case "ASSISTANT":
-		markdownContent.WriteString("🤖 **ASSISTANT**:\n\n")
-		style = "info"
-	case "USER":
-		markdownContent.WriteString("👤 **USER**:\n\n")
-		if hasError {
-			style = "error"
-		} else {
-			style = "success"
-		}
-	case "SYSTEM":
-		markdownContent.WriteString("⚙️ **SYSTEM**:\n\n")
-		style = "warning"
-	default:
-		markdownContent.WriteString(fmt.Sprintf("**%s**:\n\n", speaker))
-		style = "info"
-	}
-
-	// Add clean content (no ANSI codes)
-	if content != "" {
-		markdownContent.WriteString(content)
-	}
-
-	// Add raw JSON disclosure at the end with pretty formatting
-	markdownContent.WriteString("\n\n<details>\n<summary>Show JSON</summary>\n\n```json\n")
-
-	// Pretty-format the JSON if possible
-	if strings.HasPrefix(rawJSONLine, "{") {
-		var jsonObj interface{}
-		if err := json.Unmarshal([]byte(rawJSONLine), &jsonObj); err == nil {
-			if prettyJSON, err := json.MarshalIndent(jsonObj, "", "  "); err == nil {
-				markdownContent.WriteString(string(prettyJSON))
-			} else {
-				// Fallback to raw JSON if formatting fails
-				markdownContent.WriteString(rawJSONLine)
-			}
-		} else {
-			// Fallback to raw JSON if parsing fails
-			markdownContent.WriteString(rawJSONLine)
-		}
-	} else {
-		// Not JSON, just show as-is
-		markdownContent.WriteString(rawJSONLine)
-	}
-
-	markdownContent.WriteString("\n```\n\n</details>")
-
-	// Create context to ensure unique annotations
-	context := fmt.Sprintf("chat-message-%d", lineNumber)
-
-	// Execute buildkite-agent annotate command
-	cmd := exec.Command("buildkite-agent", "annotate",
-		"--style", style,
-		"--context", context,
-		"--priority", "5")
-
-	cmd.Stdin = strings.NewReader(markdownContent.String())
-
-	// Run the command and capture any errors
-	if err := cmd.Run(); err != nil {
-		log.Printf("Warning: Failed to create Buildkite annotation: %v", err)
-	}
-}
-
-// extractCleanJSONContentWithErrorCheck extracts clean content from JSON message without ANSI codes and detects errors
-func extractCleanJSONContentWithErrorCheck(msg Message) (speaker, content string, hasError bool) {
+// formatJSONMessage extracts the speaker, content, and error state from a
+// parsed message. Content is always plain text (no ANSI escapes) so it can
+// be reused as-is by every sink; styling is applied separately by sinks that
+// want it (see StdioSink). state supplies the tool_use_id -> tool name
+// lookup so tool_result items can be rendered by the same ToolRenderer that
+// handled the matching tool_use. tools lists the names of any tools the
+// message's content items referenced, for --skip-tool/--keep-tool filtering.
+func formatJSONMessage(msg Message, state *TranscriptState) (speaker, content string, hasError bool, tools []string) {
 	switch msg.Type {
 	case "system":
 		if msg.Subtype == "init" {
 			return "SYSTEM", fmt.Sprintf("Session initialized (ID: %s, Model: %s)",
-				msg.SessionID, msg.Model), false
+				msg.SessionID, msg.Model), false, nil
 		}
-		return "SYSTEM", "System message", false
+		return "SYSTEM", "System message", false, nil
 
 	case "assistant":
 		speaker = "ASSISTANT"
@@ -169,58 +53,17 @@ func extractCleanJSONContentWithErrorCheck(msg Message) (speaker, content string
 						contentParts = append(contentParts, contentItem.Text)
 					}
 				case "tool_use":
-					toolInput := ""
-					if contentItem.Input != nil {
-						if inputBytes, err := json.MarshalIndent(contentItem.Input, "", "  "); err == nil {
-							toolInput = string(inputBytes)
-						}
-					}
-
+					tools = append(tools, contentItem.Name)
 					toolDesc := fmt.Sprintf("🔧 Using tool: %s", contentItem.Name)
-					if toolInput != "" && toolInput != "{}" {
-						// Check if content needs progressive disclosure (multiple lines OR very long)
-						lines := strings.Split(toolInput, "\n")
-						const maxPreviewLength = 300
-
-						needsDisclosure := len(lines) > 2 || len(toolInput) > maxPreviewLength
-
-						if !needsDisclosure {
-							// Short input, show it all
-							toolDesc += fmt.Sprintf(" with %s", toolInput)
-						} else {
-							// Long input, show preview and put rest in disclosure
-							var preview, remaining string
-
-							if len(lines) > 2 {
-								// Multiple lines: show first 2 lines
-								preview = strings.Join(lines[:2], "\n")
-								remaining = strings.Join(lines[2:], "\n")
-							} else {
-								// Single long line: truncate at reasonable length
-								if len(toolInput) > maxPreviewLength {
-									preview = toolInput[:maxPreviewLength] + "..."
-									remaining = toolInput[maxPreviewLength:]
-								} else {
-									preview = toolInput
-									remaining = ""
-								}
-							}
-
-							if remaining != "" {
-								// Use HTML details/summary for collapsible content
-								toolDesc += fmt.Sprintf(" with %s\n\n<details>\n<summary>Show more input...</summary>\n\n```json\n%s\n```\n\n</details>",
-									preview, remaining)
-							} else {
-								toolDesc += fmt.Sprintf(" with %s", preview)
-							}
-						}
+					if rendered := rendererFor(contentItem.Name).RenderUse(contentItem.Input); rendered != "" {
+						toolDesc += "\n\n" + rendered
 					}
 					contentParts = append(contentParts, toolDesc)
 				}
 			}
 			content = strings.Join(contentParts, "\n\n")
 		}
-		return speaker, content, false
+		return speaker, content, false, tools
 
 	case "user":
 		speaker = "USER"
@@ -234,6 +77,14 @@ func extractCleanJSONContentWithErrorCheck(msg Message) (speaker, content string
 						hasError = true
 					}
 
+					toolName := ""
+					if state != nil {
+						toolName = state.ToolUseNames[contentItem.ToolUseID]
+					}
+					if toolName != "" {
+						tools = append(tools, toolName)
+					}
+
 					// Extract and display the actual tool result content
 					var resultContent string
 					if contentItem.Text != "" {
@@ -255,48 +106,7 @@ func extractCleanJSONContentWithErrorCheck(msg Message) (speaker, content string
 					}
 
 					if resultContent != "" {
-						errorIndicator := "✅ Tool result:"
-						if contentItem.IsError {
-							errorIndicator = "❌ Tool error:"
-						}
-
-						// Check if content needs progressive disclosure (multiple lines OR very long)
-						lines := strings.Split(resultContent, "\n")
-						const maxPreviewLength = 400
-
-						needsDisclosure := len(lines) > 2 || len(resultContent) > maxPreviewLength
-
-						if !needsDisclosure {
-							// Short content, show it all
-							contentParts = append(contentParts, errorIndicator+"\n"+resultContent)
-						} else {
-							// Long content, show preview and put rest in disclosure
-							var preview, remaining string
-
-							if len(lines) > 2 {
-								// Multiple lines: show first 2 lines
-								preview = strings.Join(lines[:2], "\n")
-								remaining = strings.Join(lines[2:], "\n")
-							} else {
-								// Single long line: truncate at reasonable length
-								if len(resultContent) > maxPreviewLength {
-									preview = resultContent[:maxPreviewLength] + "..."
-									remaining = resultContent[maxPreviewLength:]
-								} else {
-									preview = resultContent
-									remaining = ""
-								}
-							}
-
-							if remaining != "" {
-								// Use HTML details/summary for collapsible content
-								disclosureContent := fmt.Sprintf("%s\n%s\n\n<details>\n<summary>Show more...</summary>\n\n```\n%s\n```\n\n</details>",
-									errorIndicator, preview, remaining)
-								contentParts = append(contentParts, disclosureContent)
-							} else {
-								contentParts = append(contentParts, errorIndicator+"\n"+preview)
-							}
-						}
+						contentParts = append(contentParts, rendererFor(toolName).RenderResult(resultContent, contentItem.IsError))
 					} else {
 						contentParts = append(contentParts, "✅ Tool result received")
 					}
@@ -306,12 +116,12 @@ func extractCleanJSONContentWithErrorCheck(msg Message) (speaker, content string
 			}
 			content = strings.Join(contentParts, "\n\n")
 		}
-		return speaker, content, hasError
+		return speaker, content, hasError, tools
 
 	default:
 		speaker = strings.ToUpper(msg.Type)
 		content = "Unknown message type"
-		return speaker, content, false
+		return speaker, content, false, nil
 	}
 }
 
@@ -352,6 +162,10 @@ type ChatEntry struct {
 	Timestamp  string
 	IsJSON     bool
 	RawLine    string
+	HasError   bool
+	// Tools lists the tool names this entry's tool_use/tool_result items
+	// referenced, used by --skip-tool/--keep-tool filtering.
+	Tools []string
 }
 
 func main() {
@@ -359,6 +173,16 @@ func main() {
 	startTime = time.Now()
 
 	var outputFile string
+	var sinkSpec string
+	var colorSpec string
+	var annotationModeSpec string
+	var pricingFile string
+	var usageJSONFile string
+	var skipTool string
+	var keepTool string
+	var skipType string
+	var minLevel string
+	var grepPattern string
 	var inputSource string
 
 	// Parse command line arguments
@@ -378,6 +202,86 @@ func main() {
 			}
 			outputFile = args[i+1]
 			i++ // Skip the next argument as it's the filename
+		case "--sink":
+			if i+1 >= len(args) {
+				fmt.Println("Error: --sink requires a spec, e.g. stdio,buildkite,html:out.html")
+				printUsage()
+				os.Exit(1)
+			}
+			sinkSpec = args[i+1]
+			i++ // Skip the next argument as it's the spec
+		case "--color":
+			if i+1 >= len(args) {
+				fmt.Println("Error: --color requires a value (auto, always, or never)")
+				printUsage()
+				os.Exit(1)
+			}
+			colorSpec = args[i+1]
+			i++ // Skip the next argument as it's the value
+		case "--annotation-mode":
+			if i+1 >= len(args) {
+				fmt.Println("Error: --annotation-mode requires a value (per-message, aggregated, or summary)")
+				printUsage()
+				os.Exit(1)
+			}
+			annotationModeSpec = args[i+1]
+			i++ // Skip the next argument as it's the value
+		case "--pricing":
+			if i+1 >= len(args) {
+				fmt.Println("Error: --pricing requires a file path")
+				printUsage()
+				os.Exit(1)
+			}
+			pricingFile = args[i+1]
+			i++ // Skip the next argument as it's the file path
+		case "--usage-json":
+			if i+1 >= len(args) {
+				fmt.Println("Error: --usage-json requires a file path")
+				printUsage()
+				os.Exit(1)
+			}
+			usageJSONFile = args[i+1]
+			i++ // Skip the next argument as it's the file path
+		case "--skip-tool":
+			if i+1 >= len(args) {
+				fmt.Println("Error: --skip-tool requires a comma-separated list of tool names")
+				printUsage()
+				os.Exit(1)
+			}
+			skipTool = args[i+1]
+			i++ // Skip the next argument as it's the list
+		case "--keep-tool":
+			if i+1 >= len(args) {
+				fmt.Println("Error: --keep-tool requires a comma-separated list of tool names")
+				printUsage()
+				os.Exit(1)
+			}
+			keepTool = args[i+1]
+			i++ // Skip the next argument as it's the list
+		case "--skip-type":
+			if i+1 >= len(args) {
+				fmt.Println("Error: --skip-type requires a comma-separated list of types (assistant, user, system)")
+				printUsage()
+				os.Exit(1)
+			}
+			skipType = args[i+1]
+			i++ // Skip the next argument as it's the list
+		case "--min-level":
+			if i+1 >= len(args) {
+				fmt.Println("Error: --min-level requires a value (info, warn, or error)")
+				printUsage()
+				os.Exit(1)
+			}
+			minLevel = args[i+1]
+			i++ // Skip the next argument as it's the value
+		case "--grep":
+			if i+1 >= len(args) {
+				fmt.Println("Error: --grep requires a regular expression")
+				printUsage()
+				os.Exit(1)
+			}
+			grepPattern = args[i+1]
+			i++ // Skip the next argument as it's the pattern
 		case "-":
 			inputSource = "-"
 		default:
@@ -419,14 +323,45 @@ func main() {
 		os.Exit(1)
 	}
 
-	// Print colorful header and process input line by line
-	fmt.Printf("%s%s=== Claude Code Chat Transcript ===%s\n", ColorCyan, ColorBold, ColorReset)
-	fmt.Println()
+	colorMode, err := parseColorMode(colorSpec)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
 
-	if outputFile != "" {
-		parseAndStreamOutputWithFile(scanner, outputFile)
-	} else {
-		parseAndStreamOutput(scanner)
+	annotationMode, err := parseAnnotationMode(annotationModeSpec)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	pricing, err := loadPricingTable(pricingFile)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	filterChain, err := buildFilterChain(skipTool, keepTool, skipType, minLevel, grepPattern)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	state := &TranscriptState{}
+
+	sink, err := buildSinks(sinkSpec, outputFile, colorMode, annotationMode, state, pricing, usageJSONFile, filterChain)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	// Print header and process input line by line
+	printHeader(colorMode)
+
+	parseAndStreamOutput(scanner, sink, state, filterChain)
+
+	if err := sink.Close(); err != nil {
+		log.Printf("Warning: error closing sinks: %v", err)
 	}
 }
 
@@ -434,49 +369,44 @@ func printUsage() {
 	fmt.Println("Usage: chat-parser <input-file>")
 	fmt.Println("       cat <input-file> | chat-parser -")
 	fmt.Println("       cat <input-file> | chat-parser - -o <output-file>")
+	fmt.Println("       cat <input-file> | chat-parser - --sink stdio,buildkite,html:out.html")
 	fmt.Println("")
 	fmt.Println("Options:")
-	fmt.Println("  -o <file>    Save output to file (only when streaming from stdin)")
+	fmt.Println("  -o <file>      Save raw input to file (only when streaming from stdin)")
+	fmt.Println("  --sink <spec>  Comma-separated sinks to fan output out to. Defaults to")
+	fmt.Println("                 \"stdio,buildkite\". Specs: stdio, buildkite, file:<path>,")
+	fmt.Println("                 jsonl:<path>, html:<path>, webhook:<url>")
+	fmt.Println("  --color <mode> auto (default), always, or never. Also honors NO_COLOR.")
+	fmt.Println("  --annotation-mode <mode>  per-message (default), aggregated, or summary")
+	fmt.Println("  --pricing <file>      YAML price table ({model, input_per_mtok, output_per_mtok})")
+	fmt.Println("  --usage-json <file>   Write a machine-readable usage/cost report to file")
+	fmt.Println("  --skip-tool <list>    Hide entries mentioning these comma-separated tools")
+	fmt.Println("  --keep-tool <list>    Hide entries except those mentioning these tools")
+	fmt.Println("                        (--skip-tool and --keep-tool are mutually exclusive)")
+	fmt.Println("  --skip-type <list>    Hide entries of these comma-separated types (assistant, user, system)")
+	fmt.Println("  --min-level <level>   Hide entries below this level: info (default), warn, or error")
+	fmt.Println("  --grep <pattern>      Only show entries whose content matches this regexp")
+	fmt.Println("                        Filters only affect presentation sinks (stdio, buildkite,")
+	fmt.Println("                        html, webhook); -o/file:/jsonl: archival sinks always get")
+	fmt.Println("                        every entry.")
 }
 
-// parseAndStreamOutput processes input line by line and prints entries immediately
-func parseAndStreamOutput(scanner *bufio.Scanner) {
+// parseAndStreamOutput processes input line by line, writing every parsed
+// entry to sink (which applies filterChain itself, per-sink, via
+// FilteredSink) and folding it into state. state.Entries only keeps entries
+// filterChain allows, since aggregated/summary annotations re-render from
+// state.Entries directly rather than going through a sink.
+func parseAndStreamOutput(scanner *bufio.Scanner, sink Sink, state *TranscriptState, filterChain FilterChain) {
 	for scanner.Scan() {
 		line := scanner.Text()
-		entry := parseLine(line)
+		entry := parseLine(line, state)
 		if entry != nil {
-			printSingleEntry(*entry)
-			createBuildkiteAnnotation(line, entry.LineNumber, entry.Timestamp)
-		}
-	}
-
-	if err := scanner.Err(); err != nil {
-		log.Fatalf("Error reading input: %v", err)
-	}
-}
-
-// parseAndStreamOutputWithFile processes input and outputs to both stdout and file
-func parseAndStreamOutputWithFile(scanner *bufio.Scanner, outputFilename string) {
-	// Create/open output file
-	outputFile, err := os.Create(outputFilename)
-	if err != nil {
-		log.Fatalf("Error creating output file '%s': %v", outputFilename, err)
-	}
-	defer outputFile.Close()
-
-	for scanner.Scan() {
-		line := scanner.Text()
-
-		// Write raw JSON line to file
-		fmt.Fprintln(outputFile, line)
-
-		// Parse and display the line
-		entry := parseLine(line)
-		if entry != nil {
-			// Print to stdout with colors
-			printSingleEntry(*entry)
-			// Create Buildkite annotation
-			createBuildkiteAnnotation(line, entry.LineNumber, entry.Timestamp)
+			if filterChain.Allow(entry) {
+				state.Entries = append(state.Entries, *entry)
+			}
+			if err := sink.Write(*entry, line); err != nil {
+				log.Printf("Warning: sink write failed: %v", err)
+			}
 		}
 	}
 
@@ -485,7 +415,7 @@ func parseAndStreamOutputWithFile(scanner *bufio.Scanner, outputFilename string)
 	}
 }
 
-func parseLine(line string) *ChatEntry {
+func parseLine(line string, state *TranscriptState) *ChatEntry {
 	line = strings.TrimSpace(line)
 	if line == "" {
 		return nil
@@ -507,7 +437,8 @@ func parseLine(line string) *ChatEntry {
 		var msg Message
 		if err := json.Unmarshal([]byte(content), &msg); err == nil {
 			entry.IsJSON = true
-			entry.Speaker, entry.Content = formatJSONMessage(msg)
+			entry.Speaker, entry.Content, entry.HasError, entry.Tools = formatJSONMessage(msg, state)
+			state.Update(msg)
 		} else {
 			// Not valid JSON, treat as plain text
 			entry.Speaker = "SYSTEM"
@@ -522,145 +453,3 @@ func parseLine(line string) *ChatEntry {
 	return entry
 }
 
-func formatJSONMessage(msg Message) (speaker, content string) {
-	switch msg.Type {
-	case "system":
-		if msg.Subtype == "init" {
-			return "SYSTEM", fmt.Sprintf("Session initialized (ID: %s, Model: %s)",
-				msg.SessionID, msg.Model)
-		}
-		return "SYSTEM", "System message"
-
-	case "assistant":
-		speaker = "ASSISTANT"
-		if len(msg.Message.Content) > 0 {
-			var contentParts []string
-			for _, content := range msg.Message.Content {
-				switch content.Type {
-				case "text":
-					if content.Text != "" {
-						contentParts = append(contentParts, content.Text)
-					}
-				case "tool_use":
-					toolInput := ""
-					if content.Input != nil {
-						if inputBytes, err := json.Marshal(content.Input); err == nil {
-							toolInput = string(inputBytes)
-						}
-					}
-					contentParts = append(contentParts,
-						fmt.Sprintf("%s🔧 Using tool: %s%s%s",
-							ColorGreen,
-							content.Name,
-							func() string {
-								if toolInput != "" && toolInput != "{}" {
-									return " with " + toolInput
-								}
-								return ""
-							}(),
-							ColorReset))
-				}
-			}
-			content = strings.Join(contentParts, "\n")
-
-			// Add usage info if available
-			// if msg.Message.Usage.OutputTokens > 0 {
-			// 	content += fmt.Sprintf("\n[Tokens: %d in, %d out]",
-			// 		msg.Message.Usage.InputTokens, msg.Message.Usage.OutputTokens)
-			// }
-		}
-
-	case "user":
-		speaker = "USER"
-		if len(msg.Message.Content) > 0 {
-			var contentParts []string
-			for _, contentItem := range msg.Message.Content {
-				if contentItem.Type == "tool_result" {
-					// Extract and display the actual tool result content
-					var resultContent string
-					if contentItem.Text != "" {
-						resultContent = contentItem.Text
-						// Try to pretty-format if it's JSON
-						if json.Valid([]byte(resultContent)) {
-							var jsonObj interface{}
-							if err := json.Unmarshal([]byte(resultContent), &jsonObj); err == nil {
-								if prettyBytes, err := json.MarshalIndent(jsonObj, "", "  "); err == nil {
-									resultContent = string(prettyBytes)
-								}
-							}
-						}
-					} else if contentItem.Content != nil {
-						// Try to extract content from the Content field
-						if contentBytes, err := json.MarshalIndent(contentItem.Content, "", "  "); err == nil {
-							resultContent = string(contentBytes)
-						}
-					}
-
-					if resultContent != "" {
-						errorIndicator := ""
-						if contentItem.IsError {
-							errorIndicator = ColorRed + "❌ Tool error:" + ColorReset
-						} else {
-							errorIndicator = ColorMagenta + "✅ Tool result:" + ColorReset
-						}
-						contentParts = append(contentParts, errorIndicator+"\n"+resultContent)
-					} else {
-						contentParts = append(contentParts, ColorMagenta+"✅ Tool result received"+ColorReset)
-					}
-				} else if contentItem.Text != "" {
-					contentParts = append(contentParts, contentItem.Text)
-				}
-			}
-			content = strings.Join(contentParts, "\n")
-		}
-
-	default:
-		speaker = strings.ToUpper(msg.Type)
-		content = "Unknown message type"
-	}
-
-	return speaker, content
-}
-
-// printSingleEntry prints a single chat entry immediately (for streaming mode)
-func printSingleEntry(entry ChatEntry) {
-	if entry.Content == "" {
-		return
-	}
-
-	// Choose color based on speaker
-	var speakerColor, contentColor string
-	switch entry.Speaker {
-	case "ASSISTANT":
-		speakerColor = ColorGreen + ColorBold
-		contentColor = ColorGreen
-	case "USER":
-		speakerColor = ColorBlue + ColorBold
-		contentColor = ColorBlue
-	case "SYSTEM":
-		speakerColor = ColorYellow + ColorBold
-		contentColor = ColorGray
-	default:
-		speakerColor = ColorWhite
-		contentColor = ColorWhite
-	}
-
-	// Format: [LINE:123] [MM:SS] SPEAKER: content
-	prefix := fmt.Sprintf("%s[%03d] %s[%s]%s %s:%s",
-		ColorGray, entry.LineNumber, ColorDim, entry.Timestamp, ColorReset, speakerColor+entry.Speaker, ColorReset)
-
-	// Handle multi-line content
-	lines := strings.Split(entry.Content, "\n")
-	for i, line := range lines {
-		if i == 0 {
-			fmt.Printf("%-45s %s%s%s\n", prefix, contentColor, line, ColorReset)
-		} else {
-			fmt.Printf("%s%s%s\n", contentColor, line, ColorReset)
-		}
-	}
-
-	// Add spacing between messages for readability
-	if entry.IsJSON {
-		fmt.Println()
-	}
-}
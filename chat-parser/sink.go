@@ -0,0 +1,328 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/mattn/go-colorable"
+)
+
+// webhookTimeout bounds how long WebhookSink waits for a single delivery.
+// WebhookSink.Write runs synchronously inside FanOutSink.Write, so a client
+// with no timeout would let one hung endpoint stall every other sink
+// (stdio, html, buildkite, ...) for the rest of the run.
+const webhookTimeout = 10 * time.Second
+
+// Sink receives parsed chat entries as they're produced and decides what to
+// do with them (print, write to disk, forward to Buildkite, etc). Multiple
+// sinks can run side by side via FanOutSink so a single pass over the input
+// can feed a terminal, a file, and Buildkite at once.
+type Sink interface {
+	// Write is called once per parsed entry, in input order. raw is the
+	// original JSONL line the entry was parsed from.
+	Write(entry ChatEntry, raw string) error
+	// Close flushes and releases any resources held by the sink. It is
+	// called once after the input is exhausted.
+	Close() error
+}
+
+// FanOutSink writes every entry to each of its member sinks in order,
+// collecting (rather than short-circuiting on) errors so one failing sink
+// doesn't stop the others from seeing the entry.
+type FanOutSink struct {
+	sinks []Sink
+}
+
+func NewFanOutSink(sinks ...Sink) *FanOutSink {
+	return &FanOutSink{sinks: sinks}
+}
+
+func (f *FanOutSink) Write(entry ChatEntry, raw string) error {
+	var errs []string
+	for _, s := range f.sinks {
+		if err := s.Write(entry, raw); err != nil {
+			errs = append(errs, err.Error())
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("fan-out write: %s", strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+func (f *FanOutSink) Close() error {
+	var errs []string
+	for _, s := range f.sinks {
+		if err := s.Close(); err != nil {
+			errs = append(errs, err.Error())
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("fan-out close: %s", strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+// StdioSink prints entries to stdout using the colored, human-oriented
+// format the tool has always used in streaming mode. It writes through
+// go-colorable so styling renders correctly on Windows consoles, and
+// respects --color / NO_COLOR by disabling styling entirely when asked.
+type StdioSink struct {
+	out     io.Writer
+	enabled bool
+}
+
+func NewStdioSink(mode ColorMode) *StdioSink {
+	return &StdioSink{
+		out:     colorable.NewColorableStdout(),
+		enabled: colorEnabled(mode),
+	}
+}
+
+func (s *StdioSink) Write(entry ChatEntry, raw string) error {
+	if entry.Content == "" {
+		return nil
+	}
+
+	speakerColor, ok := speakerColors[entry.Speaker]
+	if !ok {
+		speakerColor = defaultSpeakerColor
+	}
+	contentColor, ok := contentColors[entry.Speaker]
+	if !ok {
+		contentColor = defaultContentColor
+	}
+
+	lineNum := fmt.Sprintf("[%03d]", entry.LineNumber)
+	timestamp := fmt.Sprintf("[%s]", entry.Timestamp)
+	speaker := entry.Speaker + ":"
+	if s.enabled {
+		lineNum = lineNumberColor.Sprint(lineNum)
+		timestamp = timestampColor.Sprint(timestamp)
+		speaker = speakerColor.Sprint(speaker)
+	}
+	prefix := fmt.Sprintf("%s %s %s", lineNum, timestamp, speaker)
+
+	// Handle multi-line content
+	lines := strings.Split(entry.Content, "\n")
+	for i, line := range lines {
+		if s.enabled {
+			line = contentColor.Sprint(line)
+		}
+		if i == 0 {
+			fmt.Fprintf(s.out, "%-45s %s\n", prefix, line)
+		} else {
+			fmt.Fprintln(s.out, line)
+		}
+	}
+
+	// Add spacing between messages for readability
+	if entry.IsJSON {
+		fmt.Fprintln(s.out)
+	}
+
+	return nil
+}
+
+func (s *StdioSink) Close() error {
+	return nil
+}
+
+// RawFileSink writes every raw input line, unmodified, to a file. This is
+// the behavior the tool has always provided via the `-o` flag: a faithful
+// copy of the JSONL stream alongside whatever's rendered to the terminal.
+type RawFileSink struct {
+	file *os.File
+}
+
+func NewRawFileSink(path string) (*RawFileSink, error) {
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, fmt.Errorf("creating output file %q: %w", path, err)
+	}
+	return &RawFileSink{file: file}, nil
+}
+
+func (s *RawFileSink) Write(entry ChatEntry, raw string) error {
+	_, err := fmt.Fprintln(s.file, raw)
+	return err
+}
+
+func (s *RawFileSink) Close() error {
+	return s.file.Close()
+}
+
+// jsonlRecord is the normalized shape JSONLSink emits, independent of
+// ChatEntry's in-memory layout.
+type jsonlRecord struct {
+	Line      int    `json:"line"`
+	Speaker   string `json:"speaker"`
+	Content   string `json:"content"`
+	Timestamp string `json:"timestamp"`
+}
+
+// JSONLSink writes one normalized ChatEntry JSON object per line, suitable
+// for feeding into other tooling downstream (e.g. a second pass that builds
+// a dashboard, or long-term storage for later querying).
+type JSONLSink struct {
+	file *os.File
+}
+
+func NewJSONLSink(path string) (*JSONLSink, error) {
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, fmt.Errorf("creating JSONL file %q: %w", path, err)
+	}
+	return &JSONLSink{file: file}, nil
+}
+
+func (s *JSONLSink) Write(entry ChatEntry, raw string) error {
+	if entry.Content == "" {
+		return nil
+	}
+	record := jsonlRecord{
+		Line:      entry.LineNumber,
+		Speaker:   entry.Speaker,
+		Content:   entry.Content,
+		Timestamp: entry.Timestamp,
+	}
+	encoded, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("marshaling JSONL record: %w", err)
+	}
+	_, err = fmt.Fprintln(s.file, string(encoded))
+	return err
+}
+
+func (s *JSONLSink) Close() error {
+	return s.file.Close()
+}
+
+// WebhookSink POSTs each entry, as JSON, to a configured URL. Delivery
+// failures are logged but don't interrupt processing of the rest of the
+// stream.
+type WebhookSink struct {
+	url    string
+	client *http.Client
+}
+
+func NewWebhookSink(url string) *WebhookSink {
+	return &WebhookSink{url: url, client: &http.Client{Timeout: webhookTimeout}}
+}
+
+func (s *WebhookSink) Write(entry ChatEntry, raw string) error {
+	record := jsonlRecord{
+		Line:      entry.LineNumber,
+		Speaker:   entry.Speaker,
+		Content:   entry.Content,
+		Timestamp: entry.Timestamp,
+	}
+	payload, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("marshaling webhook payload: %w", err)
+	}
+
+	resp, err := s.client.Post(s.url, "application/json", bytes.NewReader(payload))
+	if err != nil {
+		log.Printf("Warning: webhook delivery failed: %v", err)
+		return nil
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		log.Printf("Warning: webhook returned status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+func (s *WebhookSink) Close() error {
+	return nil
+}
+
+// buildSinks constructs the fan-out sink main() writes entries through,
+// based on the --sink flag (a comma-separated list of specs) and the legacy
+// -o flag. Supported specs: stdio, buildkite, jsonl:<file>, html:<file>,
+// webhook:<url>, file:<path>.
+//
+// filterChain is applied per-sink rather than to the fan-out as a whole:
+// presentation sinks (stdio, buildkite, html, webhook) only see entries the
+// chain allows, while archival sinks (RawFileSink from -o/file:, JSONLSink)
+// always see every entry, so --skip-tool/--skip-type/etc. can hide noisy
+// entries from annotations without also dropping them from the raw record.
+func buildSinks(sinkSpec string, legacyOutputFile string, colorMode ColorMode, annotationMode AnnotationMode, state *TranscriptState, pricing map[string]ModelPricing, usageJSONFile string, filterChain FilterChain) (Sink, error) {
+	var sinks []Sink
+	presentation := func(s Sink) Sink { return NewFilteredSink(s, filterChain) }
+
+	if legacyOutputFile != "" {
+		rawSink, err := NewRawFileSink(legacyOutputFile)
+		if err != nil {
+			return nil, err
+		}
+		sinks = append(sinks, rawSink)
+	}
+
+	if sinkSpec == "" {
+		// Default to the tool's historical behavior: print to the
+		// terminal and create a Buildkite annotation per message.
+		sinks = append(sinks, presentation(NewStdioSink(colorMode)), presentation(NewBuildkiteAnnotationSink(annotationMode, state)))
+	} else {
+		for _, spec := range strings.Split(sinkSpec, ",") {
+			spec = strings.TrimSpace(spec)
+			if spec == "" {
+				continue
+			}
+
+			name, arg, hasArg := strings.Cut(spec, ":")
+
+			switch name {
+			case "stdio":
+				sinks = append(sinks, presentation(NewStdioSink(colorMode)))
+			case "buildkite":
+				sinks = append(sinks, presentation(NewBuildkiteAnnotationSink(annotationMode, state)))
+			case "file":
+				if !hasArg || arg == "" {
+					return nil, fmt.Errorf("sink %q requires a file path, e.g. file:out.jsonl", spec)
+				}
+				rawSink, err := NewRawFileSink(arg)
+				if err != nil {
+					return nil, err
+				}
+				sinks = append(sinks, rawSink)
+			case "jsonl":
+				if !hasArg || arg == "" {
+					return nil, fmt.Errorf("sink %q requires a file path, e.g. jsonl:out.jsonl", spec)
+				}
+				jsonlSink, err := NewJSONLSink(arg)
+				if err != nil {
+					return nil, err
+				}
+				sinks = append(sinks, jsonlSink)
+			case "html":
+				if !hasArg || arg == "" {
+					return nil, fmt.Errorf("sink %q requires a file path, e.g. html:out.html", spec)
+				}
+				sinks = append(sinks, presentation(NewHTMLTranscriptSink(arg)))
+			case "webhook":
+				if !hasArg || arg == "" {
+					return nil, fmt.Errorf("sink %q requires a URL, e.g. webhook:https://example.com/hook", spec)
+				}
+				sinks = append(sinks, presentation(NewWebhookSink(arg)))
+			default:
+				return nil, fmt.Errorf("unknown sink %q", spec)
+			}
+		}
+	}
+
+	// The usage report isn't selectable via --sink: it's a cross-cutting
+	// end-of-run summary derived from TranscriptState, always produced
+	// alongside whatever sinks the user picked.
+	sinks = append(sinks, NewUsageReportSink(state, pricing, usageJSONFile))
+
+	return NewFanOutSink(sinks...), nil
+}
@@ -0,0 +1,424 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// ToolRenderer formats the input and result of a specific tool (Bash, Read,
+// Write, Edit, Grep, ...) into the human-readable markdown the rest of the
+// pipeline already uses. Sinks never call these directly; formatJSONMessage
+// looks one up via rendererFor and falls back to genericToolRenderer for
+// tools it doesn't recognize.
+type ToolRenderer interface {
+	// Match reports whether this renderer handles the named tool.
+	Match(name string) bool
+	// RenderUse formats a tool_use content item's Input field.
+	RenderUse(input interface{}) string
+	// RenderResult formats a tool_result content item's extracted text.
+	RenderResult(result string, isError bool) string
+}
+
+// toolRenderers is checked in order; the first match wins. Keep
+// genericToolRenderer out of this list since it's the explicit fallback
+// returned by rendererFor.
+var toolRenderers = []ToolRenderer{
+	bashToolRenderer{},
+	readToolRenderer{},
+	writeEditToolRenderer{},
+	grepGlobToolRenderer{},
+	webFetchToolRenderer{},
+}
+
+// rendererFor returns the ToolRenderer registered for name, or
+// genericToolRenderer if none matches.
+func rendererFor(name string) ToolRenderer {
+	for _, r := range toolRenderers {
+		if r.Match(name) {
+			return r
+		}
+	}
+	return genericToolRenderer{}
+}
+
+// inputString reads a string field out of a tool_use Input, which decodes
+// from JSON as map[string]interface{}. Returns "" if the field is missing or
+// not a string.
+func inputString(input interface{}, key string) string {
+	m, ok := input.(map[string]interface{})
+	if !ok {
+		return ""
+	}
+	s, _ := m[key].(string)
+	return s
+}
+
+// withDisclosure renders body as-is if it's short and single-line, otherwise
+// shows a short preview with the rest tucked behind a collapsible <details>
+// block, fenced as `fence` (empty for no fence). This is the same
+// progressive-disclosure shape the tool has always used for long tool output.
+func withDisclosure(body, fence string, maxPreviewLength int) string {
+	lines := strings.Split(body, "\n")
+	if len(lines) <= 2 && len(body) <= maxPreviewLength {
+		return fenced(body, fence)
+	}
+
+	var preview, remaining string
+	if len(lines) > 2 {
+		preview = strings.Join(lines[:2], "\n")
+		remaining = strings.Join(lines[2:], "\n")
+	} else {
+		preview = body[:maxPreviewLength] + "..."
+		remaining = body[maxPreviewLength:]
+	}
+
+	return fmt.Sprintf("%s\n\n<details>\n<summary>Show more...</summary>\n\n%s\n\n</details>",
+		fenced(preview, fence), fenced(remaining, fence))
+}
+
+func fenced(body, fence string) string {
+	if fence == "" {
+		return body
+	}
+	return fmt.Sprintf("```%s\n%s\n```", fence, body)
+}
+
+// languageForPath guesses a fenced-code-block language tag from a file
+// extension, for Read's output.
+func languageForPath(path string) string {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".go":
+		return "go"
+	case ".py":
+		return "python"
+	case ".js", ".mjs":
+		return "javascript"
+	case ".ts", ".tsx":
+		return "typescript"
+	case ".json":
+		return "json"
+	case ".yaml", ".yml":
+		return "yaml"
+	case ".sh", ".bash":
+		return "bash"
+	case ".md":
+		return "markdown"
+	default:
+		return ""
+	}
+}
+
+// genericToolRenderer is the fallback used for tools with no dedicated
+// renderer: pretty-print the input as JSON and show results with the same
+// progressive-disclosure rules the tool has always applied.
+type genericToolRenderer struct{}
+
+func (genericToolRenderer) Match(name string) bool { return true }
+
+func (genericToolRenderer) RenderUse(input interface{}) string {
+	if input == nil {
+		return ""
+	}
+	encoded, err := json.MarshalIndent(input, "", "  ")
+	if err != nil || string(encoded) == "{}" {
+		return ""
+	}
+	return withDisclosure(string(encoded), "json", 300)
+}
+
+func (genericToolRenderer) RenderResult(result string, isError bool) string {
+	label := "✅ Tool result:"
+	if isError {
+		label = "❌ Tool error:"
+	}
+	return label + "\n" + withDisclosure(result, "", 400)
+}
+
+// bashToolRenderer renders Bash commands as a fenced shell block and their
+// output with a pass/fail badge.
+type bashToolRenderer struct{}
+
+func (bashToolRenderer) Match(name string) bool { return name == "Bash" }
+
+func (bashToolRenderer) RenderUse(input interface{}) string {
+	command := inputString(input, "command")
+	if command == "" {
+		return ""
+	}
+	desc := inputString(input, "description")
+	var body strings.Builder
+	if desc != "" {
+		body.WriteString(desc + "\n\n")
+	}
+	body.WriteString(withDisclosure(command, "bash", 300))
+	return body.String()
+}
+
+func (bashToolRenderer) RenderResult(result string, isError bool) string {
+	badge := "✅ Exit 0"
+	if isError {
+		badge = "❌ Command failed"
+	}
+	if result == "" {
+		return badge
+	}
+	return badge + "\n" + withDisclosure(result, "", 400)
+}
+
+// readToolRenderer renders Read calls as a path:line-range header followed
+// by the file contents in a language-inferred fenced block.
+type readToolRenderer struct{}
+
+func (readToolRenderer) Match(name string) bool { return name == "Read" }
+
+func (readToolRenderer) RenderUse(input interface{}) string {
+	path := inputString(input, "file_path")
+	if path == "" {
+		return ""
+	}
+	m, _ := input.(map[string]interface{})
+	header := fmt.Sprintf("📄 %s", path)
+	if offset, ok := m["offset"]; ok {
+		if limit, ok := m["limit"]; ok {
+			header = fmt.Sprintf("📄 %s:%v-%v", path, offset, limit)
+		}
+	}
+	return header
+}
+
+func (readToolRenderer) RenderResult(result string, isError bool) string {
+	if isError {
+		return "❌ Tool error:\n" + withDisclosure(result, "", 400)
+	}
+	return withDisclosure(result, "", 400)
+}
+
+// writeEditToolRenderer renders Write/Edit calls as a diff-style view: the
+// new content for Write, old/new strings as -/+ lines for Edit.
+type writeEditToolRenderer struct{}
+
+func (writeEditToolRenderer) Match(name string) bool { return name == "Write" || name == "Edit" }
+
+func (writeEditToolRenderer) RenderUse(input interface{}) string {
+	path := inputString(input, "file_path")
+	if oldString := inputString(input, "old_string"); oldString != "" {
+		newString := inputString(input, "new_string")
+		diff := unifiedLineDiff(oldString, newString, 3)
+		return fmt.Sprintf("📝 %s\n\n%s", path, withDisclosure(diff, "diff", 300))
+	}
+
+	content := inputString(input, "content")
+	if content == "" {
+		return fmt.Sprintf("📝 %s", path)
+	}
+	return fmt.Sprintf("📝 %s\n\n%s", path, withDisclosure(content, languageForPath(path), 300))
+}
+
+// unifiedLineDiff renders a unified-diff-style view of the lines changed
+// between oldStr and newStr: an LCS-based line diff with `context` lines of
+// unchanged surroundings kept around each change and long unchanged runs
+// collapsed, so a one-line change inside a long old_string/new_string pair
+// (the common case for Edit) shows a handful of lines instead of dumping
+// both strings in full.
+func unifiedLineDiff(oldStr, newStr string, context int) string {
+	oldLines := strings.Split(oldStr, "\n")
+	newLines := strings.Split(newStr, "\n")
+
+	// lineDiffOps' LCS table is O(n*m) in both time and memory. That's fine
+	// for a typical Edit snippet but unbounded for a huge old_string/new_string
+	// pair, so above this size fall back to the plain full dump rather than
+	// stall rendering or blow up memory.
+	if len(oldLines)*len(newLines) > maxDiffCells {
+		return fullLineDump(oldLines, newLines)
+	}
+
+	ops := lineDiffOps(oldLines, newLines)
+
+	// Keep every changed line, plus `context` equal lines on either side of
+	// a change; collapse any equal run longer than that.
+	keep := make([]bool, len(ops))
+	for i, op := range ops {
+		if op.kind != diffEqual {
+			for d := -context; d <= context; d++ {
+				if k := i + d; k >= 0 && k < len(ops) {
+					keep[k] = true
+				}
+			}
+		}
+	}
+
+	var out strings.Builder
+	skipped := 0
+	flushSkipped := func() {
+		if skipped > 0 {
+			out.WriteString(fmt.Sprintf("  … %d unchanged line(s) …\n", skipped))
+			skipped = 0
+		}
+	}
+
+	for i, op := range ops {
+		if op.kind == diffEqual {
+			if keep[i] {
+				out.WriteString("  " + op.line + "\n")
+			} else {
+				skipped++
+			}
+			continue
+		}
+		flushSkipped()
+		prefix := "- "
+		if op.kind == diffInsert {
+			prefix = "+ "
+		}
+		out.WriteString(prefix + op.line + "\n")
+	}
+	flushSkipped()
+
+	return strings.TrimRight(out.String(), "\n")
+}
+
+// maxDiffCells bounds lineDiffOps' O(n*m) LCS table to about 8MB of ints
+// (1,000,000 cells), comfortably past any real Edit call but well short of
+// the "a few thousand lines on each side" case that would otherwise
+// allocate hundreds of megabytes.
+const maxDiffCells = 1_000_000
+
+// fullLineDump renders oldLines and newLines as a full delete-then-insert
+// dump with no diffing, the fallback for old_string/new_string pairs too
+// large for lineDiffOps' LCS table.
+func fullLineDump(oldLines, newLines []string) string {
+	var out strings.Builder
+	for _, line := range oldLines {
+		out.WriteString("- " + line + "\n")
+	}
+	for _, line := range newLines {
+		out.WriteString("+ " + line + "\n")
+	}
+	return strings.TrimRight(out.String(), "\n")
+}
+
+type diffKind int
+
+const (
+	diffEqual diffKind = iota
+	diffDelete
+	diffInsert
+)
+
+type diffOp struct {
+	kind diffKind
+	line string
+}
+
+// lineDiffOps computes a line-level diff between old and new lines using
+// the longest common subsequence, so unrelated changes within a large
+// old_string/new_string pair are rendered as a handful of -/+ lines with
+// context rather than a full delete-then-insert of everything.
+func lineDiffOps(oldLines, newLines []string) []diffOp {
+	n, m := len(oldLines), len(newLines)
+	lcs := make([][]int, n+1)
+	for i := range lcs {
+		lcs[i] = make([]int, m+1)
+	}
+	for i := n - 1; i >= 0; i-- {
+		for j := m - 1; j >= 0; j-- {
+			if oldLines[i] == newLines[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	var ops []diffOp
+	i, j := 0, 0
+	for i < n && j < m {
+		switch {
+		case oldLines[i] == newLines[j]:
+			ops = append(ops, diffOp{diffEqual, oldLines[i]})
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			ops = append(ops, diffOp{diffDelete, oldLines[i]})
+			i++
+		default:
+			ops = append(ops, diffOp{diffInsert, newLines[j]})
+			j++
+		}
+	}
+	for ; i < n; i++ {
+		ops = append(ops, diffOp{diffDelete, oldLines[i]})
+	}
+	for ; j < m; j++ {
+		ops = append(ops, diffOp{diffInsert, newLines[j]})
+	}
+	return ops
+}
+
+func (writeEditToolRenderer) RenderResult(result string, isError bool) string {
+	if isError {
+		return "❌ Tool error:\n" + withDisclosure(result, "", 400)
+	}
+	if result == "" {
+		return "✅ Tool result received"
+	}
+	return "✅ " + result
+}
+
+// grepGlobToolRenderer renders Grep/Glob calls as a pattern/path header and
+// their results as a bullet list of matches.
+type grepGlobToolRenderer struct{}
+
+func (grepGlobToolRenderer) Match(name string) bool { return name == "Grep" || name == "Glob" }
+
+func (grepGlobToolRenderer) RenderUse(input interface{}) string {
+	pattern := inputString(input, "pattern")
+	path := inputString(input, "path")
+	if pattern == "" {
+		return ""
+	}
+	if path != "" {
+		return fmt.Sprintf("🔎 %q in %s", pattern, path)
+	}
+	return fmt.Sprintf("🔎 %q", pattern)
+}
+
+func (grepGlobToolRenderer) RenderResult(result string, isError bool) string {
+	if isError || result == "" {
+		return genericToolRenderer{}.RenderResult(result, isError)
+	}
+	var list strings.Builder
+	for _, line := range strings.Split(strings.TrimRight(result, "\n"), "\n") {
+		if line == "" {
+			continue
+		}
+		list.WriteString("- " + line + "\n")
+	}
+	return withDisclosure(strings.TrimRight(list.String(), "\n"), "", 400)
+}
+
+// webFetchToolRenderer renders WebFetch calls as a URL/prompt header and
+// shows a preview of the fetched content.
+type webFetchToolRenderer struct{}
+
+func (webFetchToolRenderer) Match(name string) bool { return name == "WebFetch" }
+
+func (webFetchToolRenderer) RenderUse(input interface{}) string {
+	url := inputString(input, "url")
+	prompt := inputString(input, "prompt")
+	if url == "" {
+		return ""
+	}
+	if prompt != "" {
+		return fmt.Sprintf("🌐 %s — %s", url, prompt)
+	}
+	return fmt.Sprintf("🌐 %s", url)
+}
+
+func (webFetchToolRenderer) RenderResult(result string, isError bool) string {
+	return genericToolRenderer{}.RenderResult(result, isError)
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestUnifiedLineDiffChangedRegion(t *testing.T) {
+	var oldLines, newLines []string
+	for i := 1; i <= 10; i++ {
+		if i == 5 {
+			oldLines = append(oldLines, "old line 5")
+			newLines = append(newLines, "new line 5")
+			continue
+		}
+		line := "line " + strconv.Itoa(i)
+		oldLines = append(oldLines, line)
+		newLines = append(newLines, line)
+	}
+	oldStr := strings.Join(oldLines, "\n")
+	newStr := strings.Join(newLines, "\n")
+
+	diff := unifiedLineDiff(oldStr, newStr, 2)
+
+	if !strings.Contains(diff, "- old line 5") || !strings.Contains(diff, "+ new line 5") {
+		t.Fatalf("expected the changed line in the diff, got:\n%s", diff)
+	}
+	if !strings.Contains(diff, "unchanged line(s)") {
+		t.Fatalf("expected far-away unchanged lines to collapse, got:\n%s", diff)
+	}
+	if strings.Contains(diff, "line 1\n") {
+		t.Fatalf("expected line 1 (outside the context window) to be collapsed, got:\n%s", diff)
+	}
+}
+
+func TestUnifiedLineDiffFallsBackAboveCellLimit(t *testing.T) {
+	// len(oldLines) * len(newLines) must exceed maxDiffCells to trigger the
+	// full-dump fallback.
+	oldLines := make([]string, 1001)
+	newLines := make([]string, 1000)
+	for i := range oldLines {
+		oldLines[i] = "old " + strconv.Itoa(i)
+	}
+	for i := range newLines {
+		newLines[i] = "new " + strconv.Itoa(i)
+	}
+	oldStr := strings.Join(oldLines, "\n")
+	newStr := strings.Join(newLines, "\n")
+
+	diff := unifiedLineDiff(oldStr, newStr, 2)
+
+	if !strings.Contains(diff, "- old 0") || !strings.Contains(diff, "+ new 0") {
+		t.Fatalf("expected the fallback full dump to include every line, got a diff of length %d", len(diff))
+	}
+	if strings.Count(diff, "\n")+1 != len(oldLines)+len(newLines) {
+		t.Fatalf("expected fallback to dump every old and new line verbatim, got %d lines", strings.Count(diff, "\n")+1)
+	}
+}
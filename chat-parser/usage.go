@@ -0,0 +1,214 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ModelUsage accumulates token counts for a single model.
+type ModelUsage struct {
+	InputTokens  int
+	OutputTokens int
+}
+
+// ModelPricing is one row of a --pricing file: USD cost per million tokens
+// for a given model.
+type ModelPricing struct {
+	Model         string  `yaml:"model"`
+	InputPerMTok  float64 `yaml:"input_per_mtok"`
+	OutputPerMTok float64 `yaml:"output_per_mtok"`
+}
+
+// loadPricingTable reads a --pricing file (a YAML list of ModelPricing) and
+// indexes it by model name. An empty path returns a nil table, in which case
+// usage reports show token counts without cost estimates.
+func loadPricingTable(path string) (map[string]ModelPricing, error) {
+	if path == "" {
+		return nil, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading pricing file %q: %w", path, err)
+	}
+
+	var rows []ModelPricing
+	if err := yaml.Unmarshal(data, &rows); err != nil {
+		return nil, fmt.Errorf("parsing pricing file %q: %w", path, err)
+	}
+
+	table := make(map[string]ModelPricing, len(rows))
+	for _, row := range rows {
+		table[row.Model] = row
+	}
+	return table, nil
+}
+
+// modelCost estimates the USD cost of usage for model, or 0 if no pricing
+// entry exists for it.
+func modelCost(model string, usage ModelUsage, pricing map[string]ModelPricing) float64 {
+	rate, ok := pricing[model]
+	if !ok {
+		return 0
+	}
+	return float64(usage.InputTokens)/1_000_000*rate.InputPerMTok +
+		float64(usage.OutputTokens)/1_000_000*rate.OutputPerMTok
+}
+
+// sortedModels returns the models in state.ByModel in a stable order, for
+// reports that list a row per model.
+func sortedModels(state *TranscriptState) []string {
+	models := make([]string, 0, len(state.ByModel))
+	for model := range state.ByModel {
+		models = append(models, model)
+	}
+	sort.Strings(models)
+	return models
+}
+
+// renderUsageSummaryText renders the end-of-run usage report as plain text,
+// for printing to stdout.
+func renderUsageSummaryText(state *TranscriptState, pricing map[string]ModelPricing) string {
+	var body strings.Builder
+	body.WriteString("=== Usage Report ===\n")
+
+	var totalCost float64
+	for _, model := range sortedModels(state) {
+		usage := *state.ByModel[model]
+		cost := modelCost(model, usage, pricing)
+		totalCost += cost
+		body.WriteString(fmt.Sprintf("  %-30s %8d in  %8d out", model, usage.InputTokens, usage.OutputTokens))
+		if _, ok := pricing[model]; ok {
+			body.WriteString(fmt.Sprintf("  $%.4f", cost))
+		}
+		body.WriteString("\n")
+	}
+
+	body.WriteString(fmt.Sprintf("  %-30s %8d in  %8d out", "TOTAL", state.InputTokens, state.OutputTokens))
+	if len(pricing) > 0 {
+		body.WriteString(fmt.Sprintf("  $%.4f", totalCost))
+	}
+	body.WriteString("\n")
+	body.WriteString(fmt.Sprintf("  Tool calls: %d, Errors: %d\n", state.ToolCallCount, state.ErrorCount))
+
+	return body.String()
+}
+
+// renderUsageMarkdown renders the end-of-run usage report as a markdown
+// table, for a Buildkite annotation.
+func renderUsageMarkdown(state *TranscriptState, pricing map[string]ModelPricing) string {
+	var body strings.Builder
+	body.WriteString("### Usage Report\n\n")
+	body.WriteString("| Model | Input tokens | Output tokens | Cost |\n")
+	body.WriteString("|---|---|---|---|\n")
+
+	var totalCost float64
+	for _, model := range sortedModels(state) {
+		usage := *state.ByModel[model]
+		cost := modelCost(model, usage, pricing)
+		totalCost += cost
+		costCell := "—"
+		if _, ok := pricing[model]; ok {
+			costCell = fmt.Sprintf("$%.4f", cost)
+		}
+		body.WriteString(fmt.Sprintf("| %s | %d | %d | %s |\n", model, usage.InputTokens, usage.OutputTokens, costCell))
+	}
+
+	totalCostCell := "—"
+	if len(pricing) > 0 {
+		totalCostCell = fmt.Sprintf("$%.4f", totalCost)
+	}
+	body.WriteString(fmt.Sprintf("| **Total** | %d | %d | %s |\n", state.InputTokens, state.OutputTokens, totalCostCell))
+
+	body.WriteString(fmt.Sprintf("\nTool calls: %d · Errors: %d\n", state.ToolCallCount, state.ErrorCount))
+	return body.String()
+}
+
+// usageJSONModel is one row of the --usage-json dump.
+type usageJSONModel struct {
+	Model        string  `json:"model"`
+	InputTokens  int     `json:"input_tokens"`
+	OutputTokens int     `json:"output_tokens"`
+	CostUSD      float64 `json:"cost_usd,omitempty"`
+}
+
+// usageJSONReport is the machine-readable shape written by --usage-json.
+type usageJSONReport struct {
+	Models       []usageJSONModel `json:"models"`
+	InputTokens  int              `json:"input_tokens"`
+	OutputTokens int              `json:"output_tokens"`
+	CostUSD      float64          `json:"cost_usd,omitempty"`
+	ToolCalls    int              `json:"tool_calls"`
+	Errors       int              `json:"errors"`
+}
+
+// writeUsageJSON dumps the usage report as JSON to path.
+func writeUsageJSON(path string, state *TranscriptState, pricing map[string]ModelPricing) error {
+	report := usageJSONReport{
+		InputTokens:  state.InputTokens,
+		OutputTokens: state.OutputTokens,
+		ToolCalls:    state.ToolCallCount,
+		Errors:       state.ErrorCount,
+	}
+
+	for _, model := range sortedModels(state) {
+		usage := *state.ByModel[model]
+		cost := modelCost(model, usage, pricing)
+		report.CostUSD += cost
+		report.Models = append(report.Models, usageJSONModel{
+			Model:        model,
+			InputTokens:  usage.InputTokens,
+			OutputTokens: usage.OutputTokens,
+			CostUSD:      cost,
+		})
+	}
+
+	encoded, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshaling usage report: %w", err)
+	}
+	return os.WriteFile(path, encoded, 0o644)
+}
+
+// UsageReportSink doesn't act on individual entries — TranscriptState
+// already accumulates usage as parseLine runs — but on Close it emits the
+// end-of-run report: a stdout summary, a Buildkite annotation, and
+// optionally a machine-readable JSON dump.
+type UsageReportSink struct {
+	state    *TranscriptState
+	pricing  map[string]ModelPricing
+	jsonPath string
+}
+
+func NewUsageReportSink(state *TranscriptState, pricing map[string]ModelPricing, jsonPath string) *UsageReportSink {
+	return &UsageReportSink{state: state, pricing: pricing, jsonPath: jsonPath}
+}
+
+func (s *UsageReportSink) Write(entry ChatEntry, raw string) error {
+	return nil
+}
+
+func (s *UsageReportSink) Close() error {
+	if len(s.state.ByModel) == 0 {
+		return nil
+	}
+
+	fmt.Print(renderUsageSummaryText(s.state, s.pricing))
+
+	if err := annotateBuildkite(renderUsageMarkdown(s.state, s.pricing), "info", "claude-usage-report"); err != nil {
+		return err
+	}
+
+	if s.jsonPath != "" {
+		if err := writeUsageJSON(s.jsonPath, s.state, s.pricing); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}